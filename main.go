@@ -33,20 +33,23 @@ func main() {
 		log.Fatal("Error creating audio directory:", err)
 	}
 
-	// Initialize audio processor
-	processor := audioProcessor.NewProcessor()
-
-	// Load existing audio files into cache
-	processor.LoadAudioCache()
+	// Initialize audio processor (opens its DCA cache)
+	processor, err := audioProcessor.NewProcessor()
+	if err != nil {
+		log.Fatal("Error creating audio processor:", err)
+	}
+	defer processor.Close()
 
 	// Update yt-dlp on startup
-	processor.UpdateYtDlp()
+	if err := processor.UpdateYtDlp(); err != nil {
+		log.Printf("Error updating yt-dlp: %v", err)
+	}
 
 	// Schedule yt-dlp updates every 24 hours
 	go processor.ScheduleYtDlpUpdates()
 
 	// Create and initialize bot
-	discordBot, err := bot.New(token)
+	discordBot, err := bot.New(token, processor)
 	if err != nil {
 		log.Fatal("Error creating bot:", err)
 	}
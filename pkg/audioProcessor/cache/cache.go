@@ -0,0 +1,395 @@
+// Package cache provides a size-bounded LRU cache of downloaded DCA files,
+// indexed by a SQLite database for metadata and fuzzy title search. It
+// replaces a plain in-memory map so that repeated plays don't grow the audio
+// directory without bound and so that previously played tracks can be found
+// again by title rather than only by video ID.
+package cache
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"discord-youtube-bot/pkg/utils"
+
+	_ "modernc.org/sqlite"
+)
+
+// Entry describes a single cached DCA file and its metadata.
+type Entry struct {
+	VideoID    string
+	Title      string
+	Duration   int
+	Source     string
+	FilePath   string
+	FileSize   int64
+	SHA256     string
+	LastPlayed time.Time
+	PlayCount  int
+}
+
+// Stats summarizes the current state of the cache.
+type Stats struct {
+	Count      int
+	TotalBytes int64
+	MaxBytes   int64
+}
+
+// Cache is a size-bounded LRU of DCA files on disk, backed by a SQLite index.
+type Cache struct {
+	dir      string
+	maxBytes int64
+	db       *sql.DB
+
+	mu         sync.RWMutex
+	entries    map[string]*Entry // video ID -> entry
+	totalBytes int64
+}
+
+// New opens (creating if necessary) the cache rooted at dir: DCA files live
+// directly in dir, and its SQLite index in dir/cache.db. Files already in dir
+// that the index doesn't know about (e.g. from before this cache existed)
+// are imported with best-effort metadata. Total cached file size is bounded
+// to maxBytes, evicting least-recently-played entries first.
+func New(dir string, maxBytes int64) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(dir, "cache.db"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache database: %w", err)
+	}
+
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate cache database: %w", err)
+	}
+
+	c := &Cache{
+		dir:      dir,
+		maxBytes: maxBytes,
+		db:       db,
+		entries:  make(map[string]*Entry),
+	}
+
+	if err := c.load(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load cache index: %w", err)
+	}
+
+	if err := c.importUntracked(); err != nil {
+		log.Printf("cache: error importing untracked audio files: %v", err)
+	}
+
+	return c, nil
+}
+
+func migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS tracks (
+			video_id    TEXT PRIMARY KEY,
+			title       TEXT NOT NULL,
+			duration    INTEGER NOT NULL,
+			source      TEXT NOT NULL,
+			file_path   TEXT NOT NULL,
+			file_size   INTEGER NOT NULL,
+			sha256      TEXT NOT NULL,
+			last_played DATETIME NOT NULL,
+			play_count  INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE VIRTUAL TABLE IF NOT EXISTS tracks_fts USING fts5(video_id, title);
+	`)
+	return err
+}
+
+func (c *Cache) load() error {
+	rows, err := c.db.Query(`SELECT video_id, title, duration, source, file_path, file_size, sha256, last_played, play_count FROM tracks`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for rows.Next() {
+		var e Entry
+		var lastPlayed string
+		if err := rows.Scan(&e.VideoID, &e.Title, &e.Duration, &e.Source, &e.FilePath, &e.FileSize, &e.SHA256, &lastPlayed, &e.PlayCount); err != nil {
+			return err
+		}
+		e.LastPlayed, _ = time.Parse(time.RFC3339, lastPlayed)
+		c.entries[e.VideoID] = &e
+		c.totalBytes += e.FileSize
+	}
+	return rows.Err()
+}
+
+// importUntracked scans dir for .dca files with no matching row in the
+// index (e.g. left over from the old unbounded map-based cache) and adds
+// them with best-effort metadata, using the video ID as the title.
+func (c *Cache) importUntracked() error {
+	files, err := os.ReadDir(c.dir)
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".dca") {
+			continue
+		}
+
+		videoID, found := utils.ExtractVideoIDFromFilename(file.Name())
+		if !found {
+			continue
+		}
+
+		c.mu.RLock()
+		_, tracked := c.entries[videoID]
+		c.mu.RUnlock()
+		if tracked {
+			continue
+		}
+
+		filePath := filepath.Join(c.dir, file.Name())
+		if _, err := c.Put(videoID, videoID, "unknown", filePath, 0); err != nil {
+			log.Printf("cache: failed to import %s: %v", file.Name(), err)
+		}
+	}
+	return nil
+}
+
+// Get returns the cache entry for videoID, if present.
+func (c *Cache) Get(videoID string) (*Entry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.entries[videoID]
+	return e, ok
+}
+
+// Put records a newly downloaded DCA file in the cache and evicts
+// least-recently-played entries until the cache is back under quota.
+func (c *Cache) Put(videoID, title, source, filePath string, duration int) (*Entry, error) {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat cached file: %w", err)
+	}
+
+	sum, err := sha256File(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash cached file: %w", err)
+	}
+
+	entry := &Entry{
+		VideoID:    videoID,
+		Title:      title,
+		Duration:   duration,
+		Source:     source,
+		FilePath:   filePath,
+		FileSize:   info.Size(),
+		SHA256:     sum,
+		LastPlayed: time.Now(),
+		PlayCount:  1,
+	}
+
+	c.mu.Lock()
+	if existing, ok := c.entries[videoID]; ok {
+		c.totalBytes -= existing.FileSize
+	}
+	c.entries[videoID] = entry
+	c.totalBytes += entry.FileSize
+	c.mu.Unlock()
+
+	if err := c.persist(entry); err != nil {
+		return nil, err
+	}
+
+	c.evict()
+	return entry, nil
+}
+
+func (c *Cache) persist(e *Entry) error {
+	_, err := c.db.Exec(
+		`INSERT INTO tracks (video_id, title, duration, source, file_path, file_size, sha256, last_played, play_count)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		 ON CONFLICT(video_id) DO UPDATE SET
+		   title=excluded.title, duration=excluded.duration, source=excluded.source,
+		   file_path=excluded.file_path, file_size=excluded.file_size, sha256=excluded.sha256,
+		   last_played=excluded.last_played, play_count=excluded.play_count`,
+		e.VideoID, e.Title, e.Duration, e.Source, e.FilePath, e.FileSize, e.SHA256, e.LastPlayed.Format(time.RFC3339), e.PlayCount,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to persist cache entry: %w", err)
+	}
+
+	_, err = c.db.Exec(`DELETE FROM tracks_fts WHERE video_id = ?`, e.VideoID)
+	if err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	_, err = c.db.Exec(`INSERT INTO tracks_fts (video_id, title) VALUES (?, ?)`, e.VideoID, e.Title)
+	if err != nil {
+		return fmt.Errorf("failed to update search index: %w", err)
+	}
+	return nil
+}
+
+// Touch records a replay of videoID, updating its last-played time and play
+// count so it's less likely to be evicted next.
+func (c *Cache) Touch(videoID string) error {
+	c.mu.Lock()
+	entry, ok := c.entries[videoID]
+	if !ok {
+		c.mu.Unlock()
+		return fmt.Errorf("no cache entry for video ID %s", videoID)
+	}
+	entry.LastPlayed = time.Now()
+	entry.PlayCount++
+	lastPlayed, playCount := entry.LastPlayed, entry.PlayCount
+	c.mu.Unlock()
+
+	_, err := c.db.Exec(`UPDATE tracks SET last_played = ?, play_count = ? WHERE video_id = ?`, lastPlayed.Format(time.RFC3339), playCount, videoID)
+	return err
+}
+
+// Evict removes a single entry from the cache, deleting its DCA file.
+func (c *Cache) Evict(videoID string) error {
+	c.mu.Lock()
+	entry, ok := c.entries[videoID]
+	if ok {
+		delete(c.entries, videoID)
+		c.totalBytes -= entry.FileSize
+	}
+	c.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	if err := os.Remove(entry.FilePath); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove cached file: %w", err)
+	}
+	if _, err := c.db.Exec(`DELETE FROM tracks WHERE video_id = ?`, videoID); err != nil {
+		return fmt.Errorf("failed to remove cache entry: %w", err)
+	}
+	if _, err := c.db.Exec(`DELETE FROM tracks_fts WHERE video_id = ?`, videoID); err != nil {
+		return fmt.Errorf("failed to remove search index entry: %w", err)
+	}
+	return nil
+}
+
+// Clear evicts every cached track.
+func (c *Cache) Clear() error {
+	c.mu.RLock()
+	ids := make([]string, 0, len(c.entries))
+	for id := range c.entries {
+		ids = append(ids, id)
+	}
+	c.mu.RUnlock()
+
+	for _, id := range ids {
+		if err := c.Evict(id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// evict removes least-recently-played entries until the cache is at or under
+// its byte quota.
+func (c *Cache) evict() {
+	for {
+		c.mu.RLock()
+		overQuota := c.totalBytes > c.maxBytes
+		c.mu.RUnlock()
+		if !overQuota {
+			return
+		}
+
+		victim := c.leastRecentlyPlayed()
+		if victim == "" {
+			return
+		}
+		if err := c.Evict(victim); err != nil {
+			log.Printf("cache: failed to evict %s: %v", victim, err)
+			return
+		}
+	}
+}
+
+func (c *Cache) leastRecentlyPlayed() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var oldestID string
+	var oldestTime time.Time
+	for id, e := range c.entries {
+		if oldestID == "" || e.LastPlayed.Before(oldestTime) {
+			oldestID, oldestTime = id, e.LastPlayed
+		}
+	}
+	return oldestID
+}
+
+// Search performs a fuzzy (FTS5) match against cached titles.
+func (c *Cache) Search(query string) ([]*Entry, error) {
+	rows, err := c.db.Query(`SELECT video_id FROM tracks_fts WHERE title MATCH ? ORDER BY rank LIMIT 20`, query)
+	if err != nil {
+		return nil, fmt.Errorf("cache search failed: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entries := make([]*Entry, 0, len(ids))
+	for _, id := range ids {
+		if e, ok := c.entries[id]; ok {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+// Stats reports the current size of the cache.
+func (c *Cache) Stats() Stats {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return Stats{Count: len(c.entries), TotalBytes: c.totalBytes, MaxBytes: c.maxBytes}
+}
+
+// Close releases the underlying database connection.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
+
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeFile creates a file of exactly size bytes under dir and returns its path.
+func writeFile(t *testing.T, dir, name string, size int) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, make([]byte, size), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+	return path
+}
+
+func TestPutEvictsOverQuotaLRU(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, 250)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Put("a", "Track A", "youtube", writeFile(t, dir, "a.dca", 100), 10); err != nil {
+		t.Fatalf("Put a: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+	if _, err := c.Put("b", "Track B", "youtube", writeFile(t, dir, "b.dca", 100), 10); err != nil {
+		t.Fatalf("Put b: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	// Touching a makes it more recently played than b, so pushing the cache
+	// over quota with c should evict b (the least-recently-played), not a.
+	if err := c.Touch("a"); err != nil {
+		t.Fatalf("Touch a: %v", err)
+	}
+	time.Sleep(time.Millisecond)
+
+	if _, err := c.Put("c", "Track C", "youtube", writeFile(t, dir, "c.dca", 100), 10); err != nil {
+		t.Fatalf("Put c: %v", err)
+	}
+
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("expected b to be evicted as least-recently-played, but it's still cached")
+	}
+	if _, ok := c.Get("a"); !ok {
+		t.Errorf("expected a to survive eviction (touched most recently), but it's gone")
+	}
+	if _, ok := c.Get("c"); !ok {
+		t.Errorf("expected c to be cached, but it's missing")
+	}
+
+	stats := c.Stats()
+	if stats.TotalBytes > stats.MaxBytes {
+		t.Errorf("cache still over quota after eviction: %d > %d", stats.TotalBytes, stats.MaxBytes)
+	}
+}
+
+func TestPutUnderQuotaDoesNotEvict(t *testing.T) {
+	dir := t.TempDir()
+	c, err := New(dir, 1000)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer c.Close()
+
+	if _, err := c.Put("a", "Track A", "youtube", writeFile(t, dir, "a.dca", 100), 10); err != nil {
+		t.Fatalf("Put a: %v", err)
+	}
+	if _, err := c.Put("b", "Track B", "youtube", writeFile(t, dir, "b.dca", 100), 10); err != nil {
+		t.Fatalf("Put b: %v", err)
+	}
+
+	if _, ok := c.Get("a"); !ok {
+		t.Errorf("expected a to still be cached under quota")
+	}
+	if _, ok := c.Get("b"); !ok {
+		t.Errorf("expected b to still be cached under quota")
+	}
+	if stats := c.Stats(); stats.Count != 2 {
+		t.Errorf("expected 2 entries, got %d", stats.Count)
+	}
+}
@@ -1,8 +1,7 @@
 package audioProcessor
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -11,35 +10,57 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"strings"
 	"time"
 
+	"discord-youtube-bot/pkg/audioProcessor/cache"
+	"discord-youtube-bot/pkg/audioProcessor/sources"
+	"discord-youtube-bot/pkg/audioProcessor/ytdlp"
 	"discord-youtube-bot/pkg/models"
-	"discord-youtube-bot/pkg/utils"
 )
 
+// defaultMaxCacheBytes bounds the on-disk size of the DCA cache (2GB).
+const defaultMaxCacheBytes = 2 << 30
+
 // Processor handles audio processing operations
 type Processor struct {
-	YtDlpPath  string
-	AudioCache map[string]string // Map of YouTube video ID to DCA file path
+	YtDlpPath string
+	YtDlp     *ytdlp.Client
+	Cache     *cache.Cache
 }
 
-// NewProcessor creates a new audio processor
-func NewProcessor() *Processor {
+// NewProcessor creates a new audio processor, opening its DCA cache.
+func NewProcessor() (*Processor, error) {
 	var ytDlpPath string
 	switch runtime.GOOS {
 	case "windows":
 		ytDlpPath = "cmd/yt-dlp/yt-dlp.exe"
-		default:
+	default:
 		ytDlpPath = "cmd/yt-dlp/yt-dlp"
 	}
-	return &Processor{
-		YtDlpPath:  ytDlpPath,
-		AudioCache: make(map[string]string),
+
+	audioCache, err := cache.New("audio", defaultMaxCacheBytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audio cache: %w", err)
 	}
+
+	return &Processor{
+		YtDlpPath: ytDlpPath,
+		YtDlp:     ytdlp.New(ytDlpPath),
+		Cache:     audioCache,
+	}, nil
+}
+
+// Close releases resources held by the processor, such as the cache's
+// database connection.
+func (p *Processor) Close() error {
+	return p.Cache.Close()
 }
 
-func (p *Processor) UpdateYtDlp() {
+// UpdateYtDlp downloads the yt-dlp binary if it's missing, then asks it to
+// self-update. Errors are returned to the caller rather than crashing the
+// process, since a stale or briefly-unreachable yt-dlp shouldn't take the
+// bot down.
+func (p *Processor) UpdateYtDlp() error {
 	// Determine proper download URL based on platform and architecture
 	var url string
 	switch runtime.GOOS {
@@ -56,19 +77,16 @@ func (p *Processor) UpdateYtDlp() {
 		}
 	case "darwin":
 		url = "https://github.com/yt-dlp/yt-dlp/releases/latest/download/yt-dlp_macos"
-	default:
-		log.Fatalf("Unsupported platform: %s/%s", runtime.GOOS, runtime.GOARCH)
 	}
 
 	if url == "" {
-		log.Fatalf("No download URL found for platform: %s/%s", runtime.GOOS, runtime.GOARCH)
+		return fmt.Errorf("no yt-dlp download available for platform: %s/%s", runtime.GOOS, runtime.GOARCH)
 	}
 
 	// Create the directory if needed
 	dir := filepath.Dir(p.YtDlpPath)
-	err := os.MkdirAll(dir, 0755)
-	if err != nil {
-		log.Fatalf("Failed to create directory %s: %v", dir, err)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
 	}
 
 	// Check and download yt-dlp if missing
@@ -77,26 +95,25 @@ func (p *Processor) UpdateYtDlp() {
 
 		resp, err := http.Get(url)
 		if err != nil {
-			log.Fatalf("Failed to download yt-dlp: %v", err)
+			return fmt.Errorf("failed to download yt-dlp: %w", err)
 		}
 		defer resp.Body.Close()
 
 		out, err := os.Create(p.YtDlpPath)
 		if err != nil {
-			log.Fatalf("Failed to create yt-dlp file: %v", err)
+			return fmt.Errorf("failed to create yt-dlp file: %w", err)
 		}
 
-		_, err = io.Copy(out, resp.Body)
-		if err != nil {
-			log.Fatalf("Failed to save yt-dlp binary: %v", err)
+		if _, err := io.Copy(out, resp.Body); err != nil {
+			out.Close()
+			return fmt.Errorf("failed to save yt-dlp binary: %w", err)
 		}
 		out.Close()
-		
+
 		// Set executable permissions on non-Windows systems
 		if runtime.GOOS != "windows" {
-			err = os.Chmod(p.YtDlpPath, 0755)
-			if err != nil {
-				log.Fatalf("Failed to set executable permission: %v", err)
+			if err := os.Chmod(p.YtDlpPath, 0755); err != nil {
+				return fmt.Errorf("failed to set executable permission: %w", err)
 			}
 		}
 
@@ -106,114 +123,97 @@ func (p *Processor) UpdateYtDlp() {
 	// Get absolute path for exec.Command to work correctly
 	absPath, err := filepath.Abs(p.YtDlpPath)
 	if err != nil {
-		log.Fatalf("Failed to get absolute path of yt-dlp: %v", err)
+		return fmt.Errorf("failed to get absolute path of yt-dlp: %w", err)
 	}
 
 	fmt.Printf("Checking for yt-dlp updates at: %s\n", absPath)
 	cmd := exec.Command(absPath, "-U")
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		log.Printf("Error updating yt-dlp: %v\nOutput:\n%s", err, string(output))
-		return
+		return fmt.Errorf("failed to update yt-dlp: %w\nOutput:\n%s", err, string(output))
 	}
 	fmt.Printf("yt-dlp update result: %s\n", string(output))
+	return nil
 }
 
-// ScheduleYtDlpUpdates schedules periodic updates for yt-dlp
+// ScheduleYtDlpUpdates schedules periodic updates for yt-dlp, logging (rather
+// than crashing on) any failed attempt.
 func (p *Processor) ScheduleYtDlpUpdates() {
 	ticker := time.NewTicker(24 * time.Hour)
 	defer ticker.Stop()
 
 	for range ticker.C {
-		p.UpdateYtDlp()
+		if err := p.UpdateYtDlp(); err != nil {
+			log.Printf("Error updating yt-dlp: %v", err)
+		}
 	}
 }
 
-// LoadAudioCache loads existing audio files into the cache
-func (p *Processor) LoadAudioCache() {
-	// Read the audio directory
-	files, err := os.ReadDir("audio")
+// StreamPlaylist expands query into the entries it refers to, calling yield
+// with each entry's webpage URL as soon as yt-dlp reports it - rather than
+// waiting for the whole playlist to enumerate - so the caller can start
+// playback on the first entry before the rest are even known. A single video
+// that yt-dlp reports is yielded as its webpage URL; if yt-dlp yields no
+// entries (e.g. a bare search term), yield is called once with query
+// unchanged; if playlist
+// detection fails partway through, any already-yielded entries stand and the
+// failure is logged rather than surfaced, since by then whatever yield did
+// with them (e.g. enqueueing for playback) can't be undone.
+func (p *Processor) StreamPlaylist(ctx context.Context, query string, yield func(entryURL string) error) error {
+	count := 0
+	err := p.YtDlp.PlaylistStream(ctx, query, func(info ytdlp.VideoInfo) error {
+		if info.WebpageURL == "" {
+			return nil
+		}
+		count++
+		return yield(info.WebpageURL)
+	})
 	if err != nil {
-		log.Printf("Error reading audio directory: %s", err)
-		return
-	}
-
-	// Process each .dca file
-	for _, file := range files {
-		if !file.IsDir() && strings.HasSuffix(file.Name(), ".dca") {
-			// Extract video ID from filename if possible
-			videoID, found := utils.ExtractVideoIDFromFilename(file.Name())
-			if found {
-				p.AudioCache[videoID] = filepath.Join("audio", file.Name())
-				fmt.Printf("Cached audio file for video ID %s: %s\n", videoID, file.Name())
-			}
+		if count > 0 {
+			log.Printf("Error expanding rest of playlist for %q: %v", query, err)
+			return nil
 		}
+		// Not a playlist, or lookup failed outright before yielding anything;
+		// treat it as a single entry and let the normal download path surface
+		// any real error.
+		return yield(query)
 	}
-
-	fmt.Printf("Loaded %d audio files into cache\n", len(p.AudioCache))
+	if count == 0 {
+		return yield(query)
+	}
+	return nil
 }
 
-// ProcessYouTubeAudio downloads and processes audio from a YouTube link or search query
-// Returns a reader for streaming and the file path
-func (p *Processor) ProcessYouTubeAudio(query, videoID string) (io.Reader, string, error) {
-	// If videoID is provided, check if the file already exists in cache
+// ProcessQuery resolves query against the registered source backends
+// (YouTube, SoundCloud, direct HTTP URLs, local files - see
+// pkg/audioProcessor/sources) and converts the resulting audio stream to DCA.
+// videoID, when known, is used as the cache key and is preferred over the ID
+// the source reports. Cancelling ctx aborts an in-progress resolve/download
+// (e.g. when a user skips mid-download). Returns a reader for streaming and
+// the track's display title.
+func (p *Processor) ProcessQuery(ctx context.Context, query, videoID string) (io.Reader, string, error) {
+	// If videoID is provided, check if it's already cached
 	if videoID != "" {
-		dcaFilePath := filepath.Join("audio", videoID+".dca")
-		if _, err := os.Stat(dcaFilePath); err == nil {
-			// File exists, just return the path
-			file, err := os.Open(dcaFilePath)
+		if entry, ok := p.Cache.Get(videoID); ok {
+			file, err := os.Open(entry.FilePath)
 			if err != nil {
-				return nil, "", fmt.Errorf("failed to open existing DCA file: %w", err)
+				return nil, "", fmt.Errorf("failed to open cached DCA file: %w", err)
 			}
-			return file, dcaFilePath, nil
+			p.Cache.Touch(videoID)
+			return file, entry.Title, nil
 		}
 	}
 
-	// Check if yt-dlp exists
-	absPath, err := filepath.Abs(p.YtDlpPath)
-	if err != nil {
-		log.Fatalf("Failed to get absolute path: %v", err)
-	}
-	cmd := exec.Command(absPath, "-U")
-	output, err := cmd.CombinedOutput()
+	track, stream, err := sources.Resolve(ctx, query)
 	if err != nil {
-		log.Printf("Error updating yt-dlp: %s\n%s", err, string(output))
 		return nil, "", err
 	}
-	fmt.Printf("yt-dlp update result: %s\n", string(output))
-
-	// Determine if the query is a URL or a search term
-	var ytQuery string
-	if strings.HasPrefix(query, "http") {
-		ytQuery = query
-	} else {
-		ytQuery = "ytsearch:" + query
-	}
 
-	// Get video info using yt-dlp's JSON output
-	log.Printf("Getting video info for query: %s", ytQuery)
-	jsonCmd := exec.Command(p.YtDlpPath, "-f", "251/250/249", "--print", "{\"title\": \"%(title)s\", \"duration\": %(duration)s, \"id\": \"%(id)s\", \"media_url\": \"%(url)s\"}", ytQuery)
-	jsonOutput, err := jsonCmd.Output()
-	if err != nil {
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) {
-			return nil, "", fmt.Errorf("yt-dlp failed: %s\nStderr: %s", err, exitErr.Stderr)
-		}
-		return nil, "", fmt.Errorf("failed to get video info: %w", err)
-	}
-
-	// Parse the JSON output
-	var videoInfo models.YouTubeVideoInfo
-	err = json.Unmarshal(jsonOutput, &videoInfo)
-	if err != nil {
-		return nil, "", fmt.Errorf("failed to parse video info: %w\nOutput: %s", err, string(jsonOutput))
-	}
+	log.Printf("Resolved track: %s (ID: %s, Duration: %d seconds)", track.Title, track.ID, track.Duration)
 
-	log.Printf("Found video: %s (ID: %s, Duration: %d seconds)", videoInfo.Title, videoInfo.ID, videoInfo.Duration)
-
-	// If videoID was not provided, use the one from the JSON
+	// If videoID was not provided, use the one the source reported
 	if videoID == "" {
-		videoID = videoInfo.ID
+		videoID = track.ID
 	}
 
 	// Create the DCA file path
@@ -225,44 +225,33 @@ func (p *Processor) ProcessYouTubeAudio(query, videoID string) (io.Reader, strin
 	// Create the DCA file
 	dcaFile, err := os.Create(dcaFilePath)
 	if err != nil {
+		stream.Close()
 		pipeReader.Close()
 		return nil, "", fmt.Errorf("failed to create DCA file: %w", err)
 	}
 
-	// Get the audio stream from the media URL
-	log.Printf("Downloading audio stream from media URL")
-	resp, err := http.Get(videoInfo.MediaURL)
-	if err != nil {
-		dcaFile.Close()
-		pipeReader.Close()
-		return nil, "", fmt.Errorf("failed to get media stream: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		dcaFile.Close()
-		pipeReader.Close()
-		resp.Body.Close()
-		return nil, "", fmt.Errorf("failed to get media stream: HTTP status %d", resp.StatusCode)
-	}
-
 	// Create a multiWriter to write to both the file and the pipe
 	go func() {
 		defer pipeWriter.Close()
 		defer dcaFile.Close()
-		defer resp.Body.Close()
+		defer stream.Close()
 
 		// Create a custom writer that writes to both outputs
 		multiWriter := &models.MultiWriter{Writers: []io.Writer{dcaFile, pipeWriter}}
 
 		// Convert WebM to DCA using the WebmToDCA package with our multiWriter
 		log.Printf("Converting WebM to DCA format")
-		err := convertWebmToDca(resp.Body, &models.WriteCloserWrapper{Writer: multiWriter})
+		err := convertWebmToDca(stream, &models.WriteCloserWrapper{Writer: multiWriter})
 		if err != nil {
 			log.Printf("Error converting WebM to DCA: %v", err)
 			return
 		}
 		log.Printf("Finished converting WebM to DCA format for video ID: %s", videoID)
+
+		if _, err := p.Cache.Put(videoID, track.Title, track.Source, dcaFilePath, track.Duration); err != nil {
+			log.Printf("Error caching audio for video ID %s: %v", videoID, err)
+		}
 	}()
 
-	return pipeReader, dcaFilePath, nil
+	return pipeReader, track.Title, nil
 }
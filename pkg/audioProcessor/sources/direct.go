@@ -0,0 +1,67 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+var directURLRegex = regexp.MustCompile(`^https?://`)
+
+// excludedDirectURLHosts lists domains owned by more specific sources that
+// also happen to be plain HTTP(S) URLs. YouTubeSource is the catch-all
+// fallback (Matches always returns true), so without this exclusion
+// DirectURLSource - registered ahead of it - would intercept every YouTube
+// link before YouTubeSource ever saw it.
+var excludedDirectURLHosts = regexp.MustCompile(`^(www\.)?(youtube\.com|youtu\.be|music\.youtube\.com)$`)
+
+// DirectURLSource streams audio from an arbitrary HTTP(S) URL and transcodes
+// it to Opus/WebM so it can be fed through convertWebmToDca like every other
+// source. It's registered ahead of YouTubeSource but behind SoundCloudSource,
+// so soundcloud.com links are claimed by the more specific backend first.
+type DirectURLSource struct{}
+
+func (d *DirectURLSource) Name() string { return "direct-url" }
+
+func (d *DirectURLSource) Matches(query string) bool {
+	if !directURLRegex.MatchString(query) {
+		return false
+	}
+	u, err := url.Parse(query)
+	if err != nil {
+		return true
+	}
+	return !excludedDirectURLHosts.MatchString(u.Hostname())
+}
+
+// CacheKey derives the same ID Resolve assigns, directly from query, since
+// it's just the URL's filename - no network round trip needed to know it.
+func (d *DirectURLSource) CacheKey(query string) (string, bool) {
+	title := path.Base(query)
+	return "url-" + strings.TrimSuffix(title, path.Ext(title)), true
+}
+
+func (d *DirectURLSource) Resolve(ctx context.Context, query string) (*Track, io.ReadCloser, error) {
+	resp, err := httpGet(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch audio URL: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, nil, fmt.Errorf("failed to fetch audio URL: HTTP status %d", resp.StatusCode)
+	}
+
+	opus, err := transcodeToOpusWebM(resp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to transcode audio: %w", err)
+	}
+
+	title := path.Base(query)
+	track := &Track{Title: title, ID: "url-" + strings.TrimSuffix(title, path.Ext(title)), Source: d.Name()}
+	return track, opus, nil
+}
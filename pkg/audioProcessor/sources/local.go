@@ -0,0 +1,47 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const localAudioDir = "audio/local"
+
+// LocalFileSource plays files from the audio/local/ directory, addressed as
+// "file:<name>", e.g. "-p file:track.mp3".
+type LocalFileSource struct{}
+
+func (l *LocalFileSource) Name() string { return "local-file" }
+
+func (l *LocalFileSource) Matches(query string) bool {
+	return strings.HasPrefix(query, "file:")
+}
+
+// CacheKey derives the same ID Resolve assigns, directly from query, since
+// it's just the "file:" name - no disk access needed to know it.
+func (l *LocalFileSource) CacheKey(query string) (string, bool) {
+	name := strings.TrimPrefix(query, "file:")
+	return "local-" + strings.TrimSuffix(name, filepath.Ext(name)), true
+}
+
+func (l *LocalFileSource) Resolve(ctx context.Context, query string) (*Track, io.ReadCloser, error) {
+	name := strings.TrimPrefix(query, "file:")
+	path := filepath.Join(localAudioDir, filepath.Base(name))
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open local file %s: %w", name, err)
+	}
+
+	opus, err := transcodeToOpusWebM(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to transcode local file: %w", err)
+	}
+
+	track := &Track{Title: name, ID: "local-" + strings.TrimSuffix(name, filepath.Ext(name)), Source: l.Name()}
+	return track, opus, nil
+}
@@ -0,0 +1,61 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// registry holds the registered Sources in match priority order: more
+// specific backends (local files, SoundCloud, direct URLs) are registered
+// ahead of YouTube, which acts as the catch-all fallback for anything else.
+var registry []Source
+
+func init() {
+	Register(&LocalFileSource{})
+	Register(&SoundCloudSource{})
+	Register(&DirectURLSource{})
+	Register(&YouTubeSource{YtDlpPath: defaultYtDlpPath()})
+}
+
+// Register adds a Source to the registry. Sources are tried in registration
+// order, so more specific matchers must register before general fallbacks.
+func Register(s Source) {
+	registry = append(registry, s)
+}
+
+// Match returns the first registered Source willing to handle query.
+func Match(query string) (Source, bool) {
+	for _, s := range registry {
+		if s.Matches(query) {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// Resolve finds the first matching Source and resolves query against it.
+// Cancelling ctx aborts the resolve/download in progress.
+func Resolve(ctx context.Context, query string) (*Track, io.ReadCloser, error) {
+	s, ok := Match(query)
+	if !ok {
+		return nil, nil, fmt.Errorf("no source registered for query: %s", query)
+	}
+
+	track, stream, err := s.Resolve(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", s.Name(), err)
+	}
+	return track, stream, nil
+}
+
+// CacheKey finds the first matching Source and derives query's cache key
+// from it, without resolving. It returns false if no source matches, or if
+// the matching source can't determine the key without a full resolve.
+func CacheKey(query string) (string, bool) {
+	s, ok := Match(query)
+	if !ok {
+		return "", false
+	}
+	return s.CacheKey(query)
+}
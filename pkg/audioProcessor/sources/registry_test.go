@@ -0,0 +1,34 @@
+package sources
+
+import "testing"
+
+// TestMatchPrecedence pins the registration order asserted in registry.go's
+// init(): more specific sources must claim their own domains/prefixes before
+// YouTubeSource's catch-all ever sees them. This guards against regressions
+// like DirectURLSource's regex swallowing plain youtube.com/youtu.be links.
+func TestMatchPrecedence(t *testing.T) {
+	cases := []struct {
+		query string
+		want  string
+	}{
+		{"file:track.mp3", "local-file"},
+		{"https://soundcloud.com/artist/track", "soundcloud"},
+		{"https://www.soundcloud.com/artist/track", "soundcloud"},
+		{"https://www.youtube.com/watch?v=dQw4w9WgXcQ", "youtube"},
+		{"https://youtu.be/dQw4w9WgXcQ", "youtube"},
+		{"https://music.youtube.com/watch?v=dQw4w9WgXcQ", "youtube"},
+		{"https://example.com/track.mp3", "direct-url"},
+		{"never gonna give you up", "youtube"},
+	}
+
+	for _, c := range cases {
+		s, ok := Match(c.query)
+		if !ok {
+			t.Errorf("Match(%q): no source matched", c.query)
+			continue
+		}
+		if s.Name() != c.want {
+			t.Errorf("Match(%q) = %q, want %q", c.query, s.Name(), c.want)
+		}
+	}
+}
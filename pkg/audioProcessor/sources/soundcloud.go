@@ -0,0 +1,106 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+)
+
+var soundcloudURLRegex = regexp.MustCompile(`^https?://(www\.)?soundcloud\.com/`)
+
+// SoundCloudSource resolves SoundCloud track URLs via the public api-v2
+// resolve endpoint, the same approach used by the Depado/fox bot. It needs a
+// client ID, which SoundCloud doesn't issue for server-to-server use; set
+// SOUNDCLOUD_CLIENT_ID to one harvested from the web player.
+type SoundCloudSource struct{}
+
+func (sc *SoundCloudSource) Name() string { return "soundcloud" }
+
+func (sc *SoundCloudSource) Matches(query string) bool {
+	return soundcloudURLRegex.MatchString(query)
+}
+
+type soundcloudTrack struct {
+	Title    string `json:"title"`
+	Duration int    `json:"duration"` // milliseconds
+	ID       int    `json:"id"`
+	Media    struct {
+		Transcodings []struct {
+			URL    string `json:"url"`
+			Format struct {
+				Protocol string `json:"protocol"`
+			} `json:"format"`
+		} `json:"transcodings"`
+	} `json:"media"`
+}
+
+// CacheKey can't be determined without resolving: SoundCloud's track ID is
+// only known after the resolve API call, not derivable from the URL alone.
+func (sc *SoundCloudSource) CacheKey(query string) (string, bool) {
+	return "", false
+}
+
+func (sc *SoundCloudSource) Resolve(ctx context.Context, query string) (*Track, io.ReadCloser, error) {
+	clientID := os.Getenv("SOUNDCLOUD_CLIENT_ID")
+	if clientID == "" {
+		return nil, nil, fmt.Errorf("SOUNDCLOUD_CLIENT_ID is not set")
+	}
+
+	resolveURL := "https://api-v2.soundcloud.com/resolve?url=" + url.QueryEscape(query) + "&client_id=" + clientID
+	resp, err := httpGet(ctx, resolveURL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve track: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var info soundcloudTrack
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse resolve response: %w", err)
+	}
+
+	var transcodingURL string
+	for _, t := range info.Media.Transcodings {
+		if t.Format.Protocol == "progressive" {
+			transcodingURL = t.URL
+			break
+		}
+	}
+	if transcodingURL == "" {
+		return nil, nil, fmt.Errorf("no progressive stream available for this track")
+	}
+
+	streamResp, err := httpGet(ctx, transcodingURL+"?client_id="+clientID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to resolve stream URL: %w", err)
+	}
+	defer streamResp.Body.Close()
+
+	var streamInfo struct {
+		URL string `json:"url"`
+	}
+	if err := json.NewDecoder(streamResp.Body).Decode(&streamInfo); err != nil {
+		return nil, nil, fmt.Errorf("failed to parse stream response: %w", err)
+	}
+
+	audioResp, err := httpGet(ctx, streamInfo.URL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch audio: %w", err)
+	}
+	if audioResp.StatusCode != http.StatusOK {
+		audioResp.Body.Close()
+		return nil, nil, fmt.Errorf("failed to fetch audio: HTTP status %d", audioResp.StatusCode)
+	}
+
+	opus, err := transcodeToOpusWebM(audioResp.Body)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to transcode audio: %w", err)
+	}
+
+	track := &Track{Title: info.Title, Duration: info.Duration / 1000, ID: fmt.Sprintf("sc-%d", info.ID), Source: sc.Name()}
+	return track, opus, nil
+}
@@ -0,0 +1,49 @@
+// Package sources provides a pluggable registry of audio backends. Each
+// Source claims queries matching its own prefix/URL pattern (a YouTube link,
+// a SoundCloud link, "file:...", or a direct HTTP URL) and resolves them to a
+// stream the rest of audioProcessor can convert to DCA.
+package sources
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Track describes metadata about a resolved audio track, independent of
+// which backend produced it.
+type Track struct {
+	Title    string
+	Duration int // seconds
+	ID       string
+	Source   string // Name() of the Source that resolved this track
+}
+
+// Source resolves a query (a URL or a search term) into track metadata and a
+// stream of its audio. The stream must be WebM/Opus, ready for
+// convertWebmToDca - sources whose native format isn't already Opus are
+// expected to transcode before returning (see transcodeToOpusWebM). ctx is
+// honored for cancellation (e.g. a user skipping mid-resolve/mid-download).
+type Source interface {
+	// Name identifies the source for logging and wrapped errors.
+	Name() string
+	// Matches reports whether this source should handle query.
+	Matches(query string) bool
+	// Resolve fetches track metadata and opens a stream of its audio.
+	Resolve(ctx context.Context, query string) (*Track, io.ReadCloser, error)
+	// CacheKey derives query's cache key (matching the ID Resolve would set
+	// on the resulting Track) without performing a full resolve. It returns
+	// false if the key can't be determined ahead of resolving (e.g. a source
+	// whose ID depends on an API lookup).
+	CacheKey(query string) (string, bool)
+}
+
+// httpGet issues a cancellable GET request, shared by the sources that fetch
+// plain HTTP(S) media (direct URLs, SoundCloud's resolve/stream endpoints).
+func httpGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	return http.DefaultClient.Do(req)
+}
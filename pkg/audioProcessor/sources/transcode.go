@@ -0,0 +1,47 @@
+package sources
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+)
+
+// transcodeToOpusWebM pipes in through ffmpeg, producing a WebM/Opus stream
+// compatible with convertWebmToDca. It's used by every source whose native
+// format isn't already Opus (SoundCloud's progressive MP3 stream, direct HTTP
+// URLs, local files).
+func transcodeToOpusWebM(in io.ReadCloser) (io.ReadCloser, error) {
+	cmd := exec.Command("ffmpeg", "-i", "pipe:0", "-c:a", "libopus", "-f", "webm", "pipe:1")
+	cmd.Stdin = in
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		in.Close()
+		return nil, fmt.Errorf("failed to open ffmpeg stdout: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		in.Close()
+		return nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	return &transcodeStream{stdout: stdout, cmd: cmd, in: in}, nil
+}
+
+// transcodeStream closes the input stream and waits on the ffmpeg process
+// once the caller is done reading its output.
+type transcodeStream struct {
+	stdout io.ReadCloser
+	cmd    *exec.Cmd
+	in     io.ReadCloser
+}
+
+func (t *transcodeStream) Read(p []byte) (int, error) {
+	return t.stdout.Read(p)
+}
+
+func (t *transcodeStream) Close() error {
+	t.in.Close()
+	t.stdout.Close()
+	return t.cmd.Wait()
+}
@@ -0,0 +1,59 @@
+package sources
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+
+	"discord-youtube-bot/pkg/audioProcessor/ytdlp"
+	"discord-youtube-bot/pkg/utils"
+)
+
+// defaultYtDlpPath mirrors the platform-specific binary layout audioProcessor
+// downloads yt-dlp into.
+func defaultYtDlpPath() string {
+	if runtime.GOOS == "windows" {
+		return "cmd/yt-dlp/yt-dlp.exe"
+	}
+	return "cmd/yt-dlp/yt-dlp"
+}
+
+// youtubeAudioFormat is the yt-dlp format selector for Opus audio-only
+// streams, preferred highest-to-lowest bitrate.
+const youtubeAudioFormat = "251/250/249"
+
+// YouTubeSource resolves YouTube links and search queries via the
+// structured ytdlp.Client. It is the catch-all fallback: it's registered
+// last, so it only ever sees queries no more specific source claimed.
+type YouTubeSource struct {
+	YtDlpPath string
+}
+
+func (y *YouTubeSource) Name() string { return "youtube" }
+
+func (y *YouTubeSource) Matches(query string) bool { return true }
+
+// CacheKey extracts the video ID directly from query when it's a YouTube
+// URL, without resolving. It returns false for a bare search term, whose
+// video ID isn't known until yt-dlp resolves it.
+func (y *YouTubeSource) CacheKey(query string) (string, bool) {
+	return utils.ExtractVideoIDFromURL(query)
+}
+
+func (y *YouTubeSource) Resolve(ctx context.Context, query string) (*Track, io.ReadCloser, error) {
+	client := ytdlp.New(y.YtDlpPath)
+
+	info, err := client.Info(ctx, query)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get video info: %w", err)
+	}
+
+	stream, err := client.Stream(ctx, info.ID, youtubeAudioFormat)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get media stream: %w", err)
+	}
+
+	track := &Track{Title: info.Title, Duration: int(info.Duration), ID: info.ID, Source: y.Name()}
+	return track, stream, nil
+}
@@ -0,0 +1,161 @@
+// Package ytdlp wraps yt-dlp invocations behind a context-aware Client, so
+// callers can cancel an in-flight lookup or download (e.g. when a user skips
+// mid-download) and receive a typed error instead of parsing stderr
+// themselves.
+package ytdlp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+// Client runs yt-dlp and parses its output into VideoInfo values.
+type Client struct {
+	YtDlpPath string
+}
+
+// New creates a Client that invokes the yt-dlp binary at ytDlpPath.
+func New(ytDlpPath string) *Client {
+	return &Client{YtDlpPath: ytDlpPath}
+}
+
+// Info looks up metadata for a single video or search query. query may be a
+// URL or a bare search term, in which case it's resolved via yt-dlp's
+// ytsearch pseudo-URL.
+func (c *Client) Info(ctx context.Context, query string) (*VideoInfo, error) {
+	ytQuery := query
+	if !strings.HasPrefix(query, "http") {
+		ytQuery = "ytsearch:" + query
+	}
+
+	output, err := c.run(ctx, "-j", "--no-playlist", ytQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	var info VideoInfo
+	if err := json.Unmarshal(output, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse video info: %w", err)
+	}
+	return &info, nil
+}
+
+// Playlist expands url into the VideoInfo of each entry it contains. It
+// buffers the full result; callers that want to start acting on entries as
+// yt-dlp reports them (e.g. enqueueing playlist tracks for playback as soon
+// as each is found, rather than waiting for the whole playlist to enumerate)
+// should use PlaylistStream instead.
+func (c *Client) Playlist(ctx context.Context, url string) ([]VideoInfo, error) {
+	var entries []VideoInfo
+	err := c.PlaylistStream(ctx, url, func(info VideoInfo) error {
+		entries = append(entries, info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// PlaylistStream expands url into its entries via yt-dlp's flat-playlist
+// dump-json output (one JSON object per line), calling yield as each line
+// arrives rather than buffering the whole output first. Returning an error
+// from yield stops the yt-dlp process early. Cancelling ctx aborts it too.
+func (c *Client) PlaylistStream(ctx context.Context, url string, yield func(VideoInfo) error) error {
+	cmd := exec.CommandContext(ctx, c.YtDlpPath, "--flat-playlist", "-j", url)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to open yt-dlp stdout: %w", err)
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start yt-dlp: %w", err)
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	var yieldErr error
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var info VideoInfo
+		if err := json.Unmarshal([]byte(line), &info); err != nil {
+			continue // skip a malformed line rather than aborting the whole playlist
+		}
+		if yieldErr = yield(info); yieldErr != nil {
+			cmd.Process.Kill()
+			break
+		}
+	}
+	scanErr := scanner.Err()
+	waitErr := cmd.Wait()
+
+	if yieldErr != nil {
+		return yieldErr
+	}
+	if scanErr != nil {
+		return fmt.Errorf("failed to read yt-dlp output: %w", scanErr)
+	}
+	if waitErr != nil {
+		return classifyError(stderr.String())
+	}
+	return nil
+}
+
+// Stream resolves videoID's direct media URL for the given yt-dlp format
+// selector (e.g. "251/250/249") and returns a reader for its content. The
+// returned ReadCloser must be closed by the caller. Cancelling ctx aborts
+// both the yt-dlp lookup and, once started, the HTTP download.
+func (c *Client) Stream(ctx context.Context, videoID, format string) (io.ReadCloser, error) {
+	output, err := c.run(ctx, "-f", format, "--print", "%(url)s", "https://www.youtube.com/watch?v="+videoID)
+	if err != nil {
+		return nil, err
+	}
+
+	mediaURL := strings.TrimSpace(string(output))
+	if mediaURL == "" {
+		return nil, fmt.Errorf("%w: yt-dlp returned no media URL for %s", ErrUnavailable, videoID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build media request: %w", err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch media stream: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("failed to fetch media stream: HTTP status %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// run invokes yt-dlp with args and returns its stdout, classifying any
+// failure's stderr into one of the package's sentinel errors.
+func (c *Client) run(ctx context.Context, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, c.YtDlpPath, args...)
+	output, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, classifyError(string(exitErr.Stderr))
+		}
+		return nil, fmt.Errorf("failed to run yt-dlp: %w", err)
+	}
+	return output, nil
+}
@@ -0,0 +1,41 @@
+package ytdlp
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors classified from yt-dlp's stderr output. Callers should use
+// errors.Is against these rather than matching on error message text.
+var (
+	ErrGeoBlocked    = errors.New("video is geo-blocked")
+	ErrPrivate       = errors.New("video is private")
+	ErrAgeRestricted = errors.New("video is age-restricted")
+	ErrUnavailable   = errors.New("video is unavailable")
+	ErrRateLimited   = errors.New("rate limited by yt-dlp")
+)
+
+// classifyError matches known yt-dlp failure messages in stderr against the
+// package's sentinel errors, falling back to a generic wrapped error when
+// nothing matches. The raw message is always preserved in the returned
+// error's text for logging.
+func classifyError(stderr string) error {
+	trimmed := strings.TrimSpace(stderr)
+	lower := strings.ToLower(trimmed)
+
+	switch {
+	case strings.Contains(lower, "private video"):
+		return fmt.Errorf("%w: %s", ErrPrivate, trimmed)
+	case strings.Contains(lower, "sign in to confirm your age"), strings.Contains(lower, "age-restricted"):
+		return fmt.Errorf("%w: %s", ErrAgeRestricted, trimmed)
+	case strings.Contains(lower, "not available in your country"), strings.Contains(lower, "blocked it in your country"):
+		return fmt.Errorf("%w: %s", ErrGeoBlocked, trimmed)
+	case strings.Contains(lower, "http error 429"), strings.Contains(lower, "too many requests"):
+		return fmt.Errorf("%w: %s", ErrRateLimited, trimmed)
+	case strings.Contains(lower, "video unavailable"), strings.Contains(lower, "this video is not available"):
+		return fmt.Errorf("%w: %s", ErrUnavailable, trimmed)
+	default:
+		return fmt.Errorf("yt-dlp: %s", trimmed)
+	}
+}
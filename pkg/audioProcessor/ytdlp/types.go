@@ -0,0 +1,44 @@
+package ytdlp
+
+// VideoInfo mirrors a single entry of yt-dlp's -j/--dump-json output,
+// modeled after the youtube_dl Rust crate's SingleVideo schema. It carries
+// more than ProcessQuery currently needs so downstream features (now-playing
+// embeds, chapter-based seek) have real data to work with once they're built.
+type VideoInfo struct {
+	ID         string      `json:"id"`
+	Title      string      `json:"title"`
+	Duration   float64     `json:"duration"`
+	WebpageURL string      `json:"webpage_url"`
+	Uploader   string      `json:"uploader"`
+	UploadDate string      `json:"upload_date"`
+	ViewCount  int64       `json:"view_count"`
+	Thumbnails []Thumbnail `json:"thumbnails"`
+	Formats    []Format    `json:"formats"`
+	Chapters   []Chapter   `json:"chapters"`
+}
+
+// Thumbnail is one entry of a VideoInfo's thumbnails array.
+type Thumbnail struct {
+	ID     string `json:"id"`
+	URL    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// Format is one entry of a VideoInfo's available download formats.
+type Format struct {
+	FormatID string  `json:"format_id"`
+	Ext      string  `json:"ext"`
+	URL      string  `json:"url"`
+	Acodec   string  `json:"acodec"`
+	Vcodec   string  `json:"vcodec"`
+	ABR      float64 `json:"abr"`
+	Filesize int64   `json:"filesize"`
+}
+
+// Chapter is one entry of a VideoInfo's chapter markers.
+type Chapter struct {
+	Title     string  `json:"title"`
+	StartTime float64 `json:"start_time"`
+	EndTime   float64 `json:"end_time"`
+}
@@ -1,28 +1,35 @@
 package bot
 
 import (
-	"encoding/binary"
+	"context"
 	"fmt"
-	"io"
-	"log"
-	"os"
-	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
 
 	"discord-youtube-bot/pkg/audioProcessor"
-	"discord-youtube-bot/pkg/utils"
+	"discord-youtube-bot/pkg/audioProcessor/sources"
+	"discord-youtube-bot/pkg/bot/slash"
 
 	"github.com/bwmarrin/discordgo"
 )
 
 // Bot represents the Discord bot application
 type Bot struct {
-	Session          *discordgo.Session
-	VoiceConnections map[string]*discordgo.VoiceConnection // Map of guild ID to voice connection
+	Session   *discordgo.Session
+	processor *audioProcessor.Processor
+
+	playersMu    sync.Mutex
+	GuildPlayers map[string]*GuildPlayer // Map of guild ID to its queue/playback state
+
+	votesMu sync.Mutex
+	votes   map[string]*VoteHolder // Map of guild ID to its in-progress vote, if any
+
+	slashCommands *slash.Registered
 }
 
-// New creates a new Bot instance
-func New(token string) (*Bot, error) {
+// New creates a new Bot instance backed by the given audio processor
+func New(token string, processor *audioProcessor.Processor) (*Bot, error) {
 	// Create Discord session
 	dg, err := discordgo.New("Bot " + token)
 	if err != nil {
@@ -31,8 +38,10 @@ func New(token string) (*Bot, error) {
 
 	// Initialize bot
 	bot := &Bot{
-		Session:          dg,
-		VoiceConnections: make(map[string]*discordgo.VoiceConnection),
+		Session:      dg,
+		processor:    processor,
+		GuildPlayers: make(map[string]*GuildPlayer),
+		votes:        make(map[string]*VoteHolder),
 	}
 
 	// Register message handler
@@ -51,21 +60,61 @@ func (b *Bot) Start() error {
 		return fmt.Errorf("error opening connection: %w", err)
 	}
 
+	// Register slash commands (global, or scoped to DEV_GUILD_ID for fast
+	// iteration) and wire their InteractionCreate handler to this Bot.
+	registered, err := slash.Register(b.Session, b)
+	if err != nil {
+		return fmt.Errorf("error registering slash commands: %w", err)
+	}
+	b.slashCommands = registered
+
 	fmt.Println("Bot is now running. Press CTRL-C to exit.")
 	return nil
 }
 
 // Stop stops the bot
 func (b *Bot) Stop() {
-	// Disconnect from all voice channels
-	for _, vc := range b.VoiceConnections {
-		vc.Disconnect()
+	if b.slashCommands != nil {
+		b.slashCommands.RemoveCommands()
+	}
+
+	// Stop playback and disconnect from all voice channels
+	b.playersMu.Lock()
+	players := make([]*GuildPlayer, 0, len(b.GuildPlayers))
+	for _, gp := range b.GuildPlayers {
+		players = append(players, gp)
+	}
+	b.playersMu.Unlock()
+
+	for _, gp := range players {
+		gp.sendControl(ControlEvent{Type: ControlStop})
+		gp.disconnect()
 	}
 
 	// Cleanly close down the Discord session
 	b.Session.Close()
 }
 
+// player returns the existing GuildPlayer for a guild, if any.
+func (b *Bot) player(guildID string) (*GuildPlayer, bool) {
+	b.playersMu.Lock()
+	defer b.playersMu.Unlock()
+	gp, ok := b.GuildPlayers[guildID]
+	return gp, ok
+}
+
+// playerOrCreate returns the GuildPlayer for a guild, creating one if necessary.
+func (b *Bot) playerOrCreate(guildID string) *GuildPlayer {
+	b.playersMu.Lock()
+	defer b.playersMu.Unlock()
+	gp, ok := b.GuildPlayers[guildID]
+	if !ok {
+		gp = newGuildPlayer(b, guildID)
+		b.GuildPlayers[guildID] = gp
+	}
+	return gp
+}
+
 // HandleMessage processes incoming Discord messages
 func (b *Bot) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 	// Ignore messages created by the bot itself
@@ -73,181 +122,417 @@ func (b *Bot) HandleMessage(s *discordgo.Session, m *discordgo.MessageCreate) {
 		return
 	}
 
-	// Create audio processor
-	processor := audioProcessor.NewProcessor()
+	content := strings.TrimSpace(m.Content)
+
+	switch {
+	case content == "-p", strings.HasPrefix(content, "-p "):
+		b.handlePlay(s, m, strings.TrimSpace(strings.TrimPrefix(content, "-p")))
+	case content == "-skip":
+		b.handleSkip(s, m)
+	case content == "-pause":
+		b.handlePause(s, m)
+	case content == "-resume":
+		b.handleResume(s, m)
+	case content == "-stop":
+		b.handleStop(s, m)
+	case content == "-queue":
+		b.handleQueue(s, m)
+	case content == "-nowplaying":
+		b.handleNowPlaying(s, m)
+	case strings.HasPrefix(content, "-seek "):
+		b.handleSeek(s, m, strings.TrimSpace(strings.TrimPrefix(content, "-seek ")))
+	case strings.HasPrefix(content, "-remove "):
+		b.handleRemove(s, m, strings.TrimSpace(strings.TrimPrefix(content, "-remove ")))
+	case content == "-shuffle":
+		b.handleShuffle(s, m)
+	case content == "-cache", strings.HasPrefix(content, "-cache "):
+		b.handleCache(s, m, strings.TrimSpace(strings.TrimPrefix(content, "-cache")))
+	case content == "-voteskip":
+		b.handleVoteSkip(s, m)
+	case content == "-forceskip":
+		b.handleForceSkip(s, m)
+	case content == "-djrole", strings.HasPrefix(content, "-djrole "):
+		b.handleSetDJRole(s, m)
+	}
+}
 
-	if strings.Contains(m.Content, "-p") {
-		// Extract the query (everything after "-p")
-		parts := strings.SplitN(m.Content, "-p", 2)
-		if len(parts) < 2 {
-			return
-		}
-		query := strings.TrimSpace(parts[1])
-		if query == "" {
-			s.ChannelMessageSend(m.ChannelID, "Please provide a YouTube link or search query after -p")
-			return
-		}
+// handlePlay resolves the query (expanding playlists) and enqueues the result(s)
+// on the guild's player, starting playback if nothing is currently playing.
+func (b *Bot) handlePlay(s *discordgo.Session, m *discordgo.MessageCreate, query string) {
+	if query == "" {
+		s.ChannelMessageSend(m.ChannelID, "Please provide a YouTube link or search query after -p")
+		return
+	}
+
+	voiceChannelID, err := b.FindUserVoiceChannel(s, m.GuildID, m.Author.ID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "You need to be in a voice channel for me to play audio.")
+		return
+	}
 
-		// Check if it's a YouTube link
-		videoID, isYouTubeURL := utils.ExtractVideoIDFromURL(query)
-		if isYouTubeURL {
-			log.Printf("Detected YouTube link with video ID: %s", videoID)
+	msg, err := b.Play(s, m.GuildID, voiceChannelID, m.ChannelID, query)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error: %s", err))
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, msg)
+}
+
+// Play resolves query (expanding playlists) and enqueues the result(s) on the
+// guild's player, starting playback if nothing is currently playing. It is
+// the shared entry point for both the -p text command and the /play slash
+// command.
+func (b *Bot) Play(s *discordgo.Session, guildID, voiceChannelID, textChannelID, query string) (string, error) {
+	gp := b.playerOrCreate(guildID)
+	gp.setChannels(voiceChannelID, textChannelID)
+
+	var sb strings.Builder
+	count := 0
+	err := b.processor.StreamPlaylist(context.Background(), query, func(entry string) error {
+		count++
+		videoID, _ := sources.CacheKey(entry)
+		item := &QueueItem{Query: entry, VideoID: videoID, Title: entry}
+		position := gp.Enqueue(s, item)
+		if position == 0 {
+			sb.WriteString(fmt.Sprintf("Playing: %s\n", entry))
 		} else {
-			log.Printf("No YouTube link detected, treating as search query: %s", query)
-			videoID = ""
-		} // Find the user's voice channel
-		voiceChannelID, err := b.FindUserVoiceChannel(s, m.GuildID, m.Author.ID)
-		if err != nil {
-			s.ChannelMessageSend(m.ChannelID, "You need to be in a voice channel for me to play audio.")
-			return
+			sb.WriteString(fmt.Sprintf("Queued at position %d: %s\n", position, entry))
 		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("error resolving playlist: %w", err)
+	}
+	if count > 1 {
+		sb.WriteString(fmt.Sprintf("Queued %d tracks.\n", count))
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
 
-		// Check if audio already exists in cache (only if we have a videoID)
-		if videoID != "" {
-			dcaFilePath, exists := processor.AudioCache[videoID]
-			if exists {
-				s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Found cached audio for video ID: %s. Playing now...", videoID))
+func (b *Bot) handleSkip(s *discordgo.Session, m *discordgo.MessageCreate) {
+	msg, err := b.Skip(m.GuildID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, err.Error())
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, msg)
+}
 
-				// Join voice channel and play the audio from file
-				go b.PlayAudio(s, m.GuildID, voiceChannelID, m.ChannelID, dcaFilePath)
-				return
-			}
-		}
+// Skip skips the guild's currently playing track.
+func (b *Bot) Skip(guildID string) (string, error) {
+	gp, ok := b.player(guildID)
+	if !ok || !gp.sendControl(ControlEvent{Type: ControlSkip}) {
+		return "", fmt.Errorf("Nothing is currently playing.")
+	}
+	return "Skipped.", nil
+}
 
-		// Send a confirmation message
-		if videoID != "" {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Processing audio from YouTube video: %s", query))
-		} else {
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Searching for: %s", query))
-		}
+func (b *Bot) handlePause(s *discordgo.Session, m *discordgo.MessageCreate) {
+	msg, err := b.Pause(m.GuildID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, err.Error())
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, msg)
+}
+
+// Pause pauses the guild's currently playing track.
+func (b *Bot) Pause(guildID string) (string, error) {
+	gp, ok := b.player(guildID)
+	if !ok || !gp.sendControl(ControlEvent{Type: ControlPause}) {
+		return "", fmt.Errorf("Nothing is currently playing.")
+	}
+	return "Paused.", nil
+}
 
-		// Process the audio asynchronously
-		go func() {
-			// Download and process the audio, getting a stream and file path
-			audioStream, dcaFilePath, err := processor.ProcessYouTubeAudio(query, videoID)
-			if err != nil {
-				errorMsg := fmt.Sprintf("Error processing audio: %s", err)
-				s.ChannelMessageSend(m.ChannelID, errorMsg)
-				return
-			}
+func (b *Bot) handleResume(s *discordgo.Session, m *discordgo.MessageCreate) {
+	gp, ok := b.player(m.GuildID)
+	if !ok || !gp.sendControl(ControlEvent{Type: ControlResume}) {
+		s.ChannelMessageSend(m.ChannelID, "Nothing is currently paused.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, "Resumed.")
+}
 
-			// Extract videoID from the file path if it wasn't provided
-			if videoID == "" {
-				baseFilename := filepath.Base(dcaFilePath)
+func (b *Bot) handleStop(s *discordgo.Session, m *discordgo.MessageCreate) {
+	msg, err := b.StopPlayback(m.GuildID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, err.Error())
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, msg)
+}
 
-				extractedID, found := utils.ExtractVideoIDFromFilename(baseFilename)
-				if found {
-					videoID = extractedID
-				}
-			}
+// StopPlayback stops playback and clears the guild's queue. (Named to avoid
+// colliding with Bot.Stop, which shuts down the bot process itself.)
+func (b *Bot) StopPlayback(guildID string) (string, error) {
+	gp, ok := b.player(guildID)
+	if !ok || !gp.sendControl(ControlEvent{Type: ControlStop}) {
+		return "", fmt.Errorf("Nothing is currently playing.")
+	}
+	return "Stopped and cleared the queue.", nil
+}
+
+func (b *Bot) handleSeek(s *discordgo.Session, m *discordgo.MessageCreate, arg string) {
+	secs, err := strconv.Atoi(arg)
+	if err != nil || secs < 0 {
+		s.ChannelMessageSend(m.ChannelID, "Usage: -seek <seconds>")
+		return
+	}
 
-			// Add to cache if we have a videoID
-			if videoID != "" {
-				processor.AudioCache[videoID] = dcaFilePath
-				log.Printf("Added audio for video ID %s to cache", videoID)
-			}
+	msg, err := b.Seek(m.GuildID, secs)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, err.Error())
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, msg)
+}
 
-			// Join voice channel and play the audio directly from the stream
-			b.PlayAudio(s, m.GuildID, voiceChannelID, m.ChannelID, audioStream)
-		}()
+// Seek skips the guild's currently playing track forward by secs seconds from
+// its current playback position; it does not seek to an absolute position or
+// backwards.
+func (b *Bot) Seek(guildID string, secs int) (string, error) {
+	if secs < 0 {
+		return "", fmt.Errorf("seek position must not be negative")
+	}
+	gp, ok := b.player(guildID)
+	if !ok || !gp.sendControl(ControlEvent{Type: ControlSeek, SeekSecs: secs}) {
+		return "", fmt.Errorf("Nothing is currently playing.")
 	}
+	return fmt.Sprintf("Skipping ahead %ds.", secs), nil
 }
 
-// FindUserVoiceChannel finds the voice channel a user is currently in
-func (b *Bot) FindUserVoiceChannel(s *discordgo.Session, guildID, userID string) (string, error) {
-	// Get guild voice states
-	guild, err := s.State.Guild(guildID)
+func (b *Bot) handleRemove(s *discordgo.Session, m *discordgo.MessageCreate, arg string) {
+	idx, err := strconv.Atoi(arg)
 	if err != nil {
-		return "", fmt.Errorf("could not find guild: %w", err)
+		s.ChannelMessageSend(m.ChannelID, "Usage: -remove <idx>")
+		return
 	}
 
-	// Find the voice channel the user is in
-	for _, vs := range guild.VoiceStates {
-		if vs.UserID == userID {
-			return vs.ChannelID, nil
-		}
+	gp, ok := b.player(m.GuildID)
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "The queue is empty.")
+		return
 	}
 
-	return "", fmt.Errorf("user not in a voice channel")
+	item, removed := gp.Remove(idx - 1)
+	if !removed {
+		s.ChannelMessageSend(m.ChannelID, "No such queue position.")
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Removed: %s", item.Title))
 }
 
-// PlayAudio joins a voice channel and plays audio from a DCA file or reader
-func (b *Bot) PlayAudio(s *discordgo.Session, guildID, voiceChannelID, textChannelID string, audioSource interface{}) {
-	// Check if we're already connected to this guild
-	vc, exists := b.VoiceConnections[guildID]
-	if exists {
-		// If we're in a different channel, disconnect first
-		if vc.ChannelID != voiceChannelID {
-			s.ChannelMessageSend(textChannelID, "I'm already in another channel")
-			return
-		}
+func (b *Bot) handleShuffle(s *discordgo.Session, m *discordgo.MessageCreate) {
+	gp, ok := b.player(m.GuildID)
+	if !ok || !gp.Shuffle() {
+		s.ChannelMessageSend(m.ChannelID, "The queue is empty.")
+		return
 	}
+	s.ChannelMessageSend(m.ChannelID, "Shuffled the queue.")
+}
 
-	// Join the voice channel if not already connected
-	if !exists || vc.ChannelID != voiceChannelID {
-		var err error
-		vc, err = s.ChannelVoiceJoin(guildID, voiceChannelID, false, true)
-		if err != nil {
-			s.ChannelMessageSend(textChannelID, fmt.Sprintf("Error joining voice channel: %s", err))
-			return
-		}
-		b.VoiceConnections[guildID] = vc
+func (b *Bot) handleQueue(s *discordgo.Session, m *discordgo.MessageCreate) {
+	msg, err := b.QueueText(m.GuildID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, err.Error())
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, msg)
+}
+
+// QueueText renders the guild's currently playing track and pending queue.
+func (b *Bot) QueueText(guildID string) (string, error) {
+	gp, ok := b.player(guildID)
+	if !ok {
+		return "", fmt.Errorf("The queue is empty.")
 	}
 
-	var reader io.Reader
-	var closeFunc func()
+	current, queue := gp.Snapshot()
+	if current == nil && len(queue) == 0 {
+		return "", fmt.Errorf("The queue is empty.")
+	}
 
-	// Determine the type of audio source
-	switch source := audioSource.(type) {
-	case string:
-		// It's a file path
-		file, err := os.Open(source)
+	var sb strings.Builder
+	if current != nil {
+		sb.WriteString(fmt.Sprintf("Now playing: %s\n", current.Title))
+	}
+	for i, item := range queue {
+		sb.WriteString(fmt.Sprintf("%d. %s\n", i+1, item.Title))
+	}
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
+
+// handleVoteSkip implements -voteskip: any non-bot user currently in the
+// playing voice channel can start or join a vote, which passes once it has
+// votes from a majority of that channel's non-bot occupants. The channel is
+// re-counted on every vote, so users leaving lower the threshold.
+func (b *Bot) handleVoteSkip(s *discordgo.Session, m *discordgo.MessageCreate) {
+	gp, ok := b.player(m.GuildID)
+	voiceChannelID := ""
+	if ok {
+		voiceChannelID = gp.currentVoiceChannelID()
+	}
+	if !ok || voiceChannelID == "" {
+		s.ChannelMessageSend(m.ChannelID, "Nothing is currently playing.")
+		return
+	}
+
+	eligible, err := nonBotVoiceMembers(s, m.GuildID, voiceChannelID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error checking voice channel: %s", err))
+		return
+	}
+	if !containsString(eligible, m.Author.ID) {
+		s.ChannelMessageSend(m.ChannelID, "You need to be in the voice channel to vote.")
+		return
+	}
+
+	vote, ok := b.activeVote(m.GuildID)
+	if !ok || vote.Action != "skip" {
+		vote = b.startVote(m.GuildID, "skip", m.Author.ID)
+		go b.expireVoteAfter(m.GuildID, voteWindow)
+	} else {
+		vote.Vote(m.Author.ID)
+	}
+
+	required := len(eligible)/2 + 1
+	count := vote.Count()
+	if count >= required {
+		gp.sendControl(ControlEvent{Type: ControlSkip})
+		b.clearVote(m.GuildID)
+		s.ChannelMessageSend(m.ChannelID, "Vote to skip passed. Skipping.")
+		return
+	}
+
+	s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Vote to skip: %d/%d needed (%d in channel). Use -voteskip to vote.", count, required, len(eligible)))
+}
+
+// handleForceSkip implements -forceskip, restricted to the guild's DJ role or
+// administrators.
+func (b *Bot) handleForceSkip(s *discordgo.Session, m *discordgo.MessageCreate) {
+	allowed, err := b.isDJOrAdmin(s, m.GuildID, m.Member)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error checking permissions: %s", err))
+		return
+	}
+	if !allowed {
+		s.ChannelMessageSend(m.ChannelID, "You need the DJ role or admin permissions to force-skip.")
+		return
+	}
+
+	gp, ok := b.player(m.GuildID)
+	if !ok || !gp.sendControl(ControlEvent{Type: ControlSkip}) {
+		s.ChannelMessageSend(m.ChannelID, "Nothing is currently playing.")
+		return
+	}
+	b.clearVote(m.GuildID)
+	s.ChannelMessageSend(m.ChannelID, "Force-skipped.")
+}
+
+// handleSetDJRole implements "-djrole @role", restricted to guild admins,
+// persisting the DJ role to config/guilds/<guild ID>.json.
+func (b *Bot) handleSetDJRole(s *discordgo.Session, m *discordgo.MessageCreate) {
+	isAdmin, err := b.isGuildAdmin(s, m.GuildID, m.Member)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error checking permissions: %s", err))
+		return
+	}
+	if !isAdmin {
+		s.ChannelMessageSend(m.ChannelID, "Only admins can set the DJ role.")
+		return
+	}
+	if len(m.MentionRoles) == 0 {
+		s.ChannelMessageSend(m.ChannelID, "Usage: -djrole @role")
+		return
+	}
+
+	cfg := &GuildConfig{DJRoleID: m.MentionRoles[0]}
+	if err := saveGuildConfig(m.GuildID, cfg); err != nil {
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error saving DJ role: %s", err))
+		return
+	}
+	s.ChannelMessageSend(m.ChannelID, "DJ role updated.")
+}
+
+// handleCache implements "-cache stats", "-cache clear", and
+// "-cache search <query>" against the processor's DCA cache.
+func (b *Bot) handleCache(s *discordgo.Session, m *discordgo.MessageCreate, arg string) {
+	sub, rest, _ := strings.Cut(arg, " ")
+
+	switch sub {
+	case "stats":
+		stats := b.processor.Cache.Stats()
+		s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Cached tracks: %d\nCache size: %.1f/%.1f MB",
+			stats.Count, float64(stats.TotalBytes)/1e6, float64(stats.MaxBytes)/1e6))
+	case "clear":
+		if err := b.processor.Cache.Clear(); err != nil {
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error clearing cache: %s", err))
+			return
+		}
+		s.ChannelMessageSend(m.ChannelID, "Cache cleared.")
+	case "search":
+		query := strings.TrimSpace(rest)
+		if query == "" {
+			s.ChannelMessageSend(m.ChannelID, "Usage: -cache search <query>")
+			return
+		}
+		entries, err := b.processor.Cache.Search(query)
 		if err != nil {
-			s.ChannelMessageSend(textChannelID, fmt.Sprintf("Error opening DCA file: %s", err))
+			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Error searching cache: %s", err))
 			return
 		}
-		reader = file
-		closeFunc = func() { file.Close() }
-	case io.Reader:
-		// It's already a reader
-		reader = source
-		// If it's also a closer, set up the close function
-		if closer, ok := source.(io.Closer); ok {
-			closeFunc = func() { closer.Close() }
-		} else {
-			closeFunc = func() {}
+		if len(entries) == 0 {
+			s.ChannelMessageSend(m.ChannelID, "No matches found.")
+			return
 		}
+		var sb strings.Builder
+		for _, e := range entries {
+			sb.WriteString(fmt.Sprintf("%s (%s)\n", e.Title, e.VideoID))
+		}
+		s.ChannelMessageSend(m.ChannelID, sb.String())
 	default:
-		s.ChannelMessageSend(textChannelID, "Invalid audio source type")
+		s.ChannelMessageSend(m.ChannelID, "Usage: -cache stats|clear|search <query>")
+	}
+}
+
+func (b *Bot) handleNowPlaying(s *discordgo.Session, m *discordgo.MessageCreate) {
+	msg, err := b.NowPlaying(m.GuildID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, err.Error())
 		return
 	}
+	s.ChannelMessageSend(m.ChannelID, msg)
+}
 
-	// Make sure we close the reader when done
-	defer closeFunc()
+// NowPlaying describes the guild's currently playing track, if any.
+func (b *Bot) NowPlaying(guildID string) (string, error) {
+	gp, ok := b.player(guildID)
+	if !ok {
+		return "", fmt.Errorf("Nothing is currently playing.")
+	}
 
-	vc.Speaking(true)
-	defer vc.Speaking(false)
+	current, _ := gp.Snapshot()
+	if current == nil {
+		return "", fmt.Errorf("Nothing is currently playing.")
+	}
+	return fmt.Sprintf("Now playing: %s", current.Title), nil
+}
 
-	for {
-		var frameLen uint16
-		err := binary.Read(reader, binary.LittleEndian, &frameLen)
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			s.ChannelMessageSend(textChannelID, fmt.Sprintf("Error reading audio frame: %s", err))
-			break
-		}
+// FindUserVoiceChannel finds the voice channel a user is currently in
+func (b *Bot) FindUserVoiceChannel(s *discordgo.Session, guildID, userID string) (string, error) {
+	// Get guild voice states
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return "", fmt.Errorf("could not find guild: %w", err)
+	}
 
-		frame := make([]byte, frameLen)
-		_, err = io.ReadFull(reader, frame)
-		if err != nil {
-			s.ChannelMessageSend(textChannelID, fmt.Sprintf("Error reading audio data: %s", err))
-			break
+	// Find the voice channel the user is in
+	for _, vs := range guild.VoiceStates {
+		if vs.UserID == userID {
+			return vs.ChannelID, nil
 		}
-
-		vc.OpusSend <- frame
 	}
-	vc.Disconnect()
-	delete(b.VoiceConnections, guildID)
+
+	return "", fmt.Errorf("user not in a voice channel")
 }
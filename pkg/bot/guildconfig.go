@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// guildConfigDir is where per-guild settings are persisted, one JSON file
+// per guild ID.
+const guildConfigDir = "config/guilds"
+
+// GuildConfig holds per-guild settings such as the DJ role.
+type GuildConfig struct {
+	DJRoleID string `json:"dj_role_id"`
+}
+
+func guildConfigPath(guildID string) string {
+	return filepath.Join(guildConfigDir, guildID+".json")
+}
+
+// loadGuildConfig reads a guild's config, returning a zero-value config if
+// none has been saved yet.
+func loadGuildConfig(guildID string) (*GuildConfig, error) {
+	data, err := os.ReadFile(guildConfigPath(guildID))
+	if os.IsNotExist(err) {
+		return &GuildConfig{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read guild config: %w", err)
+	}
+
+	var cfg GuildConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse guild config: %w", err)
+	}
+	return &cfg, nil
+}
+
+// saveGuildConfig writes a guild's config to disk.
+func saveGuildConfig(guildID string, cfg *GuildConfig) error {
+	if err := os.MkdirAll(guildConfigDir, 0755); err != nil {
+		return fmt.Errorf("failed to create guild config directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode guild config: %w", err)
+	}
+
+	if err := os.WriteFile(guildConfigPath(guildID), data, 0644); err != nil {
+		return fmt.Errorf("failed to write guild config: %w", err)
+	}
+	return nil
+}
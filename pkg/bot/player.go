@@ -0,0 +1,359 @@
+package bot
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// frameDuration is the playback duration of a single DCA/Opus frame.
+const frameDuration = 20 * time.Millisecond
+
+// QueueItem represents a single track queued for playback in a guild.
+type QueueItem struct {
+	Query   string // Original query or URL used to resolve this track
+	VideoID string // YouTube video ID, if known
+	Title   string // Display title shown in -queue / -nowplaying
+}
+
+// ControlEventType identifies the kind of playback control signal sent to a GuildPlayer.
+type ControlEventType int
+
+const (
+	ControlSkip ControlEventType = iota
+	ControlPause
+	ControlResume
+	ControlStop
+	ControlSeek
+)
+
+// ControlEvent is sent over a GuildPlayer's control channel to influence playback
+// of the track currently being streamed.
+type ControlEvent struct {
+	Type     ControlEventType
+	SeekSecs int
+}
+
+// GuildPlayer owns the voice connection and playback queue for a single guild.
+// A dedicated goroutine drains the queue and streams DCA frames to Discord,
+// honoring control signals sent over the controls channel.
+type GuildPlayer struct {
+	bot     *Bot
+	guildID string
+
+	mu             sync.Mutex
+	voiceChannelID string
+	textChannelID  string
+	vc             *discordgo.VoiceConnection
+	queue          []*QueueItem
+	current        *QueueItem
+	playing        bool
+	cancelCurrent  context.CancelFunc // cancels the in-flight resolve/download for the current item, if any
+
+	controls chan ControlEvent
+}
+
+func newGuildPlayer(b *Bot, guildID string) *GuildPlayer {
+	return &GuildPlayer{
+		bot:      b,
+		guildID:  guildID,
+		controls: make(chan ControlEvent, 4),
+	}
+}
+
+// setChannels records which voice/text channel the player should use for its
+// next join and status messages.
+func (gp *GuildPlayer) setChannels(voiceChannelID, textChannelID string) {
+	gp.mu.Lock()
+	defer gp.mu.Unlock()
+	gp.voiceChannelID = voiceChannelID
+	gp.textChannelID = textChannelID
+}
+
+// Enqueue appends item to the queue and, if nothing is currently playing,
+// starts the playback loop. It returns the item's position at the moment it
+// was enqueued, counting the current track as 0 (0 means it started playing
+// immediately; n means it is n-th in the pending queue, as -queue numbers it).
+func (gp *GuildPlayer) Enqueue(s *discordgo.Session, item *QueueItem) int {
+	gp.mu.Lock()
+	position := len(gp.queue)
+	if gp.current != nil {
+		position++ // the current track holds position 0
+	}
+	gp.queue = append(gp.queue, item)
+	startLoop := !gp.playing
+	if startLoop {
+		gp.playing = true
+	}
+	gp.mu.Unlock()
+
+	if startLoop {
+		go gp.playbackLoop(s)
+	}
+	return position
+}
+
+// Remove removes the queue item at the given 0-indexed position.
+func (gp *GuildPlayer) Remove(idx int) (*QueueItem, bool) {
+	gp.mu.Lock()
+	defer gp.mu.Unlock()
+	if idx < 0 || idx >= len(gp.queue) {
+		return nil, false
+	}
+	item := gp.queue[idx]
+	gp.queue = append(gp.queue[:idx], gp.queue[idx+1:]...)
+	return item, true
+}
+
+// Shuffle randomizes the order of the not-yet-played queue. It returns false
+// if the queue is empty.
+func (gp *GuildPlayer) Shuffle() bool {
+	gp.mu.Lock()
+	defer gp.mu.Unlock()
+	if len(gp.queue) == 0 {
+		return false
+	}
+	rand.Shuffle(len(gp.queue), func(i, j int) {
+		gp.queue[i], gp.queue[j] = gp.queue[j], gp.queue[i]
+	})
+	return true
+}
+
+// Snapshot returns the track currently playing (or nil) and a copy of the
+// pending queue.
+func (gp *GuildPlayer) Snapshot() (*QueueItem, []*QueueItem) {
+	gp.mu.Lock()
+	defer gp.mu.Unlock()
+	queue := make([]*QueueItem, len(gp.queue))
+	copy(queue, gp.queue)
+	return gp.current, queue
+}
+
+// currentVoiceChannelID returns the voice channel the player is using, or ""
+// if nothing is currently playing.
+func (gp *GuildPlayer) currentVoiceChannelID() string {
+	gp.mu.Lock()
+	defer gp.mu.Unlock()
+	if gp.current == nil {
+		return ""
+	}
+	return gp.voiceChannelID
+}
+
+// sendControl delivers a control event to the playback goroutine. It returns
+// false if nothing is currently playing. Skip and stop also cancel the
+// current item's in-flight resolve/download immediately, rather than waiting
+// for streaming to start before the control channel is drained.
+func (gp *GuildPlayer) sendControl(ev ControlEvent) bool {
+	gp.mu.Lock()
+	playing := gp.playing
+	cancel := gp.cancelCurrent
+	gp.mu.Unlock()
+	if !playing {
+		return false
+	}
+	if cancel != nil && (ev.Type == ControlSkip || ev.Type == ControlStop) {
+		cancel()
+	}
+	gp.controls <- ev
+	return true
+}
+
+// setCancel records the cancel function for the currently resolving/playing
+// item, so sendControl can cancel it on skip/stop.
+func (gp *GuildPlayer) setCancel(cancel context.CancelFunc) {
+	gp.mu.Lock()
+	gp.cancelCurrent = cancel
+	gp.mu.Unlock()
+}
+
+func (gp *GuildPlayer) disconnect() {
+	gp.mu.Lock()
+	vc := gp.vc
+	gp.vc = nil
+	gp.mu.Unlock()
+	if vc != nil {
+		vc.Disconnect()
+	}
+}
+
+// playbackLoop drains the queue one track at a time until it's empty.
+func (gp *GuildPlayer) playbackLoop(s *discordgo.Session) {
+	for {
+		gp.mu.Lock()
+		if len(gp.queue) == 0 {
+			gp.current = nil
+			gp.playing = false
+			gp.mu.Unlock()
+			return
+		}
+		item := gp.queue[0]
+		gp.queue = gp.queue[1:]
+		gp.current = item
+		gp.mu.Unlock()
+
+		gp.playItem(s, item)
+	}
+}
+
+// playItem resolves the audio for item (from cache or via the processor),
+// joins the guild's voice channel, and streams the resulting DCA frames. The
+// resolve/download is cancellable: sendControl cancels it immediately on
+// skip/stop, so a user skipping mid-download doesn't have to wait it out.
+func (gp *GuildPlayer) playItem(s *discordgo.Session, item *QueueItem) {
+	gp.mu.Lock()
+	textChannelID := gp.textChannelID
+	gp.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	gp.setCancel(cancel)
+	defer cancel()
+	defer gp.setCancel(nil)
+
+	stream, title, err := gp.bot.processor.ProcessQuery(ctx, item.Query, item.VideoID)
+	if err != nil {
+		if ctx.Err() != nil {
+			// Cancelled by a skip/stop; nothing to report. Consume that
+			// skip/stop event here so it isn't applied to the next track too.
+			for ev := range gp.controls {
+				if ev.Type == ControlSkip || ev.Type == ControlStop {
+					gp.handleControl(ev, nil)
+					break
+				}
+			}
+			return
+		}
+		s.ChannelMessageSend(textChannelID, fmt.Sprintf("Error processing audio: %s", err))
+		return
+	}
+	if title != "" {
+		item.Title = title
+	}
+	if closer, ok := stream.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	vc, err := gp.joinVoiceChannel(s)
+	if err != nil {
+		s.ChannelMessageSend(textChannelID, fmt.Sprintf("Error joining voice channel: %s", err))
+		return
+	}
+
+	gp.streamToVoice(s, vc, textChannelID, stream)
+}
+
+func (gp *GuildPlayer) joinVoiceChannel(s *discordgo.Session) (*discordgo.VoiceConnection, error) {
+	gp.mu.Lock()
+	defer gp.mu.Unlock()
+
+	if gp.vc != nil && gp.vc.ChannelID == gp.voiceChannelID {
+		return gp.vc, nil
+	}
+
+	vc, err := s.ChannelVoiceJoin(gp.guildID, gp.voiceChannelID, false, true)
+	if err != nil {
+		return nil, err
+	}
+	gp.vc = vc
+	return vc, nil
+}
+
+// streamToVoice reads DCA frames from reader and sends them to vc.OpusSend,
+// checking for control events between frames so skip/pause/resume/stop/seek
+// take effect with at most one frame of latency.
+func (gp *GuildPlayer) streamToVoice(s *discordgo.Session, vc *discordgo.VoiceConnection, textChannelID string, reader io.Reader) {
+	vc.Speaking(true)
+	defer vc.Speaking(false)
+
+	for {
+		select {
+		case ev := <-gp.controls:
+			if gp.handleControl(ev, reader) {
+				return
+			}
+		default:
+		}
+
+		frame, err := readDcaFrame(reader)
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			s.ChannelMessageSend(textChannelID, fmt.Sprintf("Error reading audio frame: %s", err))
+			return
+		}
+
+		vc.OpusSend <- frame
+	}
+}
+
+// handleControl applies a single control event. It returns true if the caller
+// should stop streaming the current track (skip/stop/EOF-while-paused).
+func (gp *GuildPlayer) handleControl(ev ControlEvent, reader io.Reader) bool {
+	switch ev.Type {
+	case ControlSkip:
+		return true
+	case ControlStop:
+		gp.clearQueue()
+		gp.disconnect()
+		return true
+	case ControlPause:
+		return gp.waitForResume()
+	case ControlSeek:
+		skipFrames(reader, ev.SeekSecs)
+	}
+	return false
+}
+
+// waitForResume blocks until a resume, skip, or stop control event arrives.
+func (gp *GuildPlayer) waitForResume() bool {
+	for ev := range gp.controls {
+		switch ev.Type {
+		case ControlResume:
+			return false
+		case ControlSkip:
+			return true
+		case ControlStop:
+			gp.clearQueue()
+			gp.disconnect()
+			return true
+		}
+	}
+	return true
+}
+
+func (gp *GuildPlayer) clearQueue() {
+	gp.mu.Lock()
+	gp.queue = nil
+	gp.mu.Unlock()
+}
+
+// readDcaFrame reads a single length-prefixed DCA/Opus frame from reader.
+func readDcaFrame(reader io.Reader) ([]byte, error) {
+	var frameLen uint16
+	if err := binary.Read(reader, binary.LittleEndian, &frameLen); err != nil {
+		return nil, err
+	}
+	frame := make([]byte, frameLen)
+	if _, err := io.ReadFull(reader, frame); err != nil {
+		return nil, err
+	}
+	return frame, nil
+}
+
+// skipFrames discards the DCA frames covering the next secs seconds of
+// playback, implementing seek-by-frame-skip for cached/streamed files.
+func skipFrames(reader io.Reader, secs int) {
+	framesToSkip := int(time.Duration(secs) * time.Second / frameDuration)
+	for i := 0; i < framesToSkip; i++ {
+		if _, err := readDcaFrame(reader); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,225 @@
+// Package slash implements the bot's Discord application command (slash
+// command) surface. It dispatches to a Controller rather than importing
+// pkg/bot directly, so that *bot.Bot can register itself without an import
+// cycle.
+package slash
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// autocompleteTimeout bounds the yt-dlp search behind /play's autocomplete.
+// Discord requires autocomplete interactions to be acknowledged within 3
+// seconds, so a search that's still running past this gets cut short and
+// degrades to no suggestions rather than leaving the interaction to error out.
+const autocompleteTimeout = 2 * time.Second
+
+// Controller is the subset of the bot's playback behavior the slash commands
+// dispatch to - the same GuildPlayer-backed logic used by the legacy text
+// commands.
+type Controller interface {
+	FindUserVoiceChannel(s *discordgo.Session, guildID, userID string) (string, error)
+	Play(s *discordgo.Session, guildID, voiceChannelID, textChannelID, query string) (string, error)
+	Skip(guildID string) (string, error)
+	Pause(guildID string) (string, error)
+	StopPlayback(guildID string) (string, error)
+	Seek(guildID string, secs int) (string, error)
+	NowPlaying(guildID string) (string, error)
+	QueueText(guildID string) (string, error)
+}
+
+var commands = []*discordgo.ApplicationCommand{
+	{
+		Name:        "play",
+		Description: "Play a track or playlist",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:         discordgo.ApplicationCommandOptionString,
+				Name:         "query",
+				Description:  "YouTube/SoundCloud URL or search query",
+				Required:     true,
+				Autocomplete: true,
+			},
+		},
+	},
+	{Name: "queue", Description: "Show the current queue"},
+	{Name: "skip", Description: "Skip the current track"},
+	{Name: "pause", Description: "Pause playback"},
+	{Name: "nowplaying", Description: "Show the currently playing track"},
+	{
+		Name:        "seek",
+		Description: "Skip ahead in the current track",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "seconds",
+				Description: "Seconds to skip ahead from the current position",
+				Required:    true,
+			},
+		},
+	},
+	{Name: "stop", Description: "Stop playback and clear the queue"},
+}
+
+// Registered holds the application commands Register created, so they can
+// be torn down again on shutdown.
+type Registered struct {
+	session  *discordgo.Session
+	guildID  string
+	commands []*discordgo.ApplicationCommand
+}
+
+// Register creates the bot's application commands and wires an
+// InteractionCreate handler that dispatches them to ctrl. If the
+// DEV_GUILD_ID environment variable is set, commands are registered to that
+// single guild for fast iteration; otherwise they're registered globally.
+func Register(s *discordgo.Session, ctrl Controller) (*Registered, error) {
+	guildID := os.Getenv("DEV_GUILD_ID")
+
+	created := make([]*discordgo.ApplicationCommand, 0, len(commands))
+	for _, cmd := range commands {
+		result, err := s.ApplicationCommandCreate(s.State.User.ID, guildID, cmd)
+		if err != nil {
+			return nil, fmt.Errorf("failed to register /%s: %w", cmd.Name, err)
+		}
+		created = append(created, result)
+	}
+
+	s.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		handleInteraction(s, i, ctrl)
+	})
+
+	return &Registered{session: s, guildID: guildID, commands: created}, nil
+}
+
+// RemoveCommands deletes every command Register created. Call it on
+// shutdown to avoid leaving stale commands registered between runs.
+func (r *Registered) RemoveCommands() {
+	for _, cmd := range r.commands {
+		if err := r.session.ApplicationCommandDelete(r.session.State.User.ID, r.guildID, cmd.ID); err != nil {
+			log.Printf("slash: failed to remove /%s: %v", cmd.Name, err)
+		}
+	}
+}
+
+func handleInteraction(s *discordgo.Session, i *discordgo.InteractionCreate, ctrl Controller) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommandAutocomplete:
+		handleAutocomplete(s, i)
+	case discordgo.InteractionApplicationCommand:
+		handleCommand(s, i, ctrl)
+	}
+}
+
+func handleCommand(s *discordgo.Session, i *discordgo.InteractionCreate, ctrl Controller) {
+	data := i.ApplicationCommandData()
+	guildID := i.GuildID
+
+	var (
+		msg string
+		err error
+	)
+
+	switch data.Name {
+	case "play":
+		query := data.Options[0].StringValue()
+		var voiceChannelID string
+		voiceChannelID, err = ctrl.FindUserVoiceChannel(s, guildID, i.Member.User.ID)
+		if err != nil {
+			err = fmt.Errorf("you need to be in a voice channel for me to play audio")
+			break
+		}
+		msg, err = ctrl.Play(s, guildID, voiceChannelID, i.ChannelID, query)
+	case "queue":
+		msg, err = ctrl.QueueText(guildID)
+	case "skip":
+		msg, err = ctrl.Skip(guildID)
+	case "pause":
+		msg, err = ctrl.Pause(guildID)
+	case "nowplaying":
+		msg, err = ctrl.NowPlaying(guildID)
+	case "seek":
+		msg, err = ctrl.Seek(guildID, int(data.Options[0].IntValue()))
+	case "stop":
+		msg, err = ctrl.StopPlayback(guildID)
+	default:
+		err = fmt.Errorf("unknown command: /%s", data.Name)
+	}
+
+	if err != nil {
+		msg = fmt.Sprintf("Error: %s", err)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{Content: msg},
+	})
+}
+
+func handleAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	if data.Name == "play" && len(data.Options) > 0 {
+		partial := data.Options[0].StringValue()
+		titles, err := searchTitles(partial)
+		if err != nil {
+			log.Printf("slash: autocomplete search failed: %v", err)
+		}
+		choices = make([]*discordgo.ApplicationCommandOptionChoice, 0, len(titles))
+		for _, title := range titles {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: title, Value: title})
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{Choices: choices},
+	})
+}
+
+// searchTitles returns up to 5 YouTube search suggestions for the partial
+// /play query, via yt-dlp's flat-playlist search. Bounded by
+// autocompleteTimeout so a slow search doesn't blow past Discord's 3-second
+// autocomplete response window.
+func searchTitles(partial string) ([]string, error) {
+	if partial == "" {
+		return nil, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), autocompleteTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, defaultYtDlpPath(), "--flat-playlist", "--print", "title", "ytsearch5:"+partial)
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("yt-dlp search failed: %w", err)
+	}
+
+	var titles []string
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" {
+			titles = append(titles, line)
+		}
+	}
+	return titles, nil
+}
+
+// defaultYtDlpPath mirrors the platform-specific binary layout audioProcessor
+// downloads yt-dlp into (see pkg/audioProcessor/sources.defaultYtDlpPath).
+func defaultYtDlpPath() string {
+	if runtime.GOOS == "windows" {
+		return "cmd/yt-dlp/yt-dlp.exe"
+	}
+	return "cmd/yt-dlp/yt-dlp"
+}
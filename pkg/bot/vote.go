@@ -0,0 +1,194 @@
+package bot
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// voteWindow is how long a vote stays open before it's discarded unvoted.
+const voteWindow = 30 * time.Second
+
+// VoteHolder tracks a single in-progress vote for a guild (e.g. a
+// -voteskip). Only one vote can be active per guild at a time.
+type VoteHolder struct {
+	Action    string
+	Initiator string
+	Expires   time.Time
+
+	mu     sync.Mutex
+	Voters map[string]bool // user ID -> voted
+}
+
+func newVoteHolder(action, initiator string) *VoteHolder {
+	return &VoteHolder{
+		Action:    action,
+		Initiator: initiator,
+		Expires:   time.Now().Add(voteWindow),
+		Voters:    map[string]bool{initiator: true},
+	}
+}
+
+func (v *VoteHolder) expired() bool {
+	return time.Now().After(v.Expires)
+}
+
+// Vote registers userID's vote and returns the new total vote count.
+func (v *VoteHolder) Vote(userID string) int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.Voters[userID] = true
+	return len(v.Voters)
+}
+
+// Count returns the current number of votes cast.
+func (v *VoteHolder) Count() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.Voters)
+}
+
+// activeVote returns the guild's in-progress vote, if any and not expired.
+func (b *Bot) activeVote(guildID string) (*VoteHolder, bool) {
+	b.votesMu.Lock()
+	defer b.votesMu.Unlock()
+
+	v, ok := b.votes[guildID]
+	if ok && v.expired() {
+		delete(b.votes, guildID)
+		return nil, false
+	}
+	return v, ok
+}
+
+// startVote begins a new vote for the guild, replacing any prior one.
+func (b *Bot) startVote(guildID, action, initiator string) *VoteHolder {
+	b.votesMu.Lock()
+	defer b.votesMu.Unlock()
+
+	v := newVoteHolder(action, initiator)
+	b.votes[guildID] = v
+	return v
+}
+
+// clearVote removes the guild's in-progress vote, if any.
+func (b *Bot) clearVote(guildID string) {
+	b.votesMu.Lock()
+	delete(b.votes, guildID)
+	b.votesMu.Unlock()
+}
+
+// expireVoteAfter removes the guild's vote once it expires, unless it has
+// already been cleared (passed, or replaced by a newer vote).
+func (b *Bot) expireVoteAfter(guildID string, after time.Duration) {
+	time.Sleep(after)
+
+	b.votesMu.Lock()
+	defer b.votesMu.Unlock()
+	if v, ok := b.votes[guildID]; ok && v.expired() {
+		delete(b.votes, guildID)
+	}
+}
+
+// nonBotVoiceMembers lists the non-bot user IDs currently in voiceChannelID.
+// It reads Member off each VoiceState directly rather than re-querying the
+// state cache by user ID, since the latter requires the privileged
+// GuildMembers intent (which this bot doesn't request) and would otherwise
+// fail to find almost every real member.
+func nonBotVoiceMembers(s *discordgo.Session, guildID, voiceChannelID string) ([]string, error) {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return nil, fmt.Errorf("could not find guild: %w", err)
+	}
+
+	var members []string
+	for _, vs := range guild.VoiceStates {
+		if vs.ChannelID != voiceChannelID {
+			continue
+		}
+		if vs.Member != nil && vs.Member.User.Bot {
+			continue
+		}
+		members = append(members, vs.UserID)
+	}
+	return members, nil
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
+// memberRoles resolves the discordgo.Role objects member holds in guildID.
+// member is taken from the triggering message/interaction rather than looked
+// up via s.State.Member, since that lookup requires the privileged
+// GuildMembers intent (which this bot doesn't request) and would otherwise
+// fail to find almost every real member.
+func (b *Bot) memberRoles(s *discordgo.Session, guildID string, member *discordgo.Member) ([]*discordgo.Role, error) {
+	guild, err := s.State.Guild(guildID)
+	if err != nil {
+		return nil, fmt.Errorf("could not find guild: %w", err)
+	}
+
+	roleByID := make(map[string]*discordgo.Role, len(guild.Roles))
+	for _, r := range guild.Roles {
+		roleByID[r.ID] = r
+	}
+
+	roles := make([]*discordgo.Role, 0, len(member.Roles))
+	for _, id := range member.Roles {
+		if r, ok := roleByID[id]; ok {
+			roles = append(roles, r)
+		}
+	}
+	return roles, nil
+}
+
+// isGuildAdmin reports whether member holds a role with administrator
+// permissions in guildID.
+func (b *Bot) isGuildAdmin(s *discordgo.Session, guildID string, member *discordgo.Member) (bool, error) {
+	roles, err := b.memberRoles(s, guildID, member)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r.Permissions&discordgo.PermissionAdministrator != 0 {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// isDJOrAdmin reports whether member is a guild admin or holds the guild's
+// configured DJ role.
+func (b *Bot) isDJOrAdmin(s *discordgo.Session, guildID string, member *discordgo.Member) (bool, error) {
+	isAdmin, err := b.isGuildAdmin(s, guildID, member)
+	if err != nil || isAdmin {
+		return isAdmin, err
+	}
+
+	cfg, err := loadGuildConfig(guildID)
+	if err != nil {
+		return false, err
+	}
+	if cfg.DJRoleID == "" {
+		return false, nil
+	}
+
+	roles, err := b.memberRoles(s, guildID, member)
+	if err != nil {
+		return false, err
+	}
+	for _, r := range roles {
+		if r.ID == cfg.DJRoleID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,55 @@
+package bot
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVoteHolderVoteCount(t *testing.T) {
+	v := newVoteHolder("skip", "initiator")
+	if got := v.Count(); got != 1 {
+		t.Fatalf("expected initiator's vote to be counted immediately, got %d", got)
+	}
+
+	if got := v.Vote("user2"); got != 2 {
+		t.Fatalf("expected 2 votes after a second voter, got %d", got)
+	}
+
+	// Voting again with the same user must not double-count.
+	if got := v.Vote("user2"); got != 2 {
+		t.Fatalf("expected a repeat vote to not increase the count, got %d", got)
+	}
+
+	if got := v.Vote("initiator"); got != 2 {
+		t.Fatalf("expected the initiator re-voting to not increase the count, got %d", got)
+	}
+}
+
+func TestVoteHolderMajorityThreshold(t *testing.T) {
+	v := newVoteHolder("skip", "a")
+	v.Vote("b")
+
+	// Mirrors the required := len(eligible)/2 + 1 majority math in
+	// handleVoteSkip: with 3 eligible voters, 2 votes should already pass.
+	required := 3/2 + 1
+	if v.Count() < required {
+		t.Fatalf("expected %d votes to meet a majority of 3, got %d", required, v.Count())
+	}
+
+	required = 5/2 + 1
+	if v.Count() >= required {
+		t.Fatalf("expected %d votes to fall short of a majority of 5, got %d >= %d", v.Count(), v.Count(), required)
+	}
+}
+
+func TestVoteHolderExpired(t *testing.T) {
+	v := newVoteHolder("skip", "a")
+	if v.expired() {
+		t.Fatal("freshly created vote should not be expired")
+	}
+
+	v.Expires = time.Now().Add(-time.Second)
+	if !v.expired() {
+		t.Fatal("vote with a past Expires time should be expired")
+	}
+}
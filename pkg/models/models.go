@@ -12,20 +12,6 @@ type Bot struct {
 	VoiceConnections map[string]*discordgo.VoiceConnection // Map of guild ID to voice connection
 }
 
-// AudioProcessor handles audio processing operations
-type AudioProcessor struct {
-	YtDlpPath  string
-	AudioCache map[string]string // Map of YouTube video ID to DCA file path
-}
-
-// YouTubeVideoInfo holds the JSON data returned by yt-dlp
-type YouTubeVideoInfo struct {
-	Title    string `json:"title"`
-	Duration int    `json:"duration"`
-	ID       string `json:"id"`
-	MediaURL string `json:"media_url"`
-}
-
 // MultiWriter is a custom writer that writes to multiple writers
 type MultiWriter struct {
 	Writers []io.Writer